@@ -0,0 +1,43 @@
+package pdf
+
+// SpaceWidth returns the advance of the font's space glyph (code point 32),
+// as a fraction of the font size (multiply by the desired FontSize to get
+// the advance in text space units). Fonts that don't define a width for the
+// space glyph fall back to the FontDescriptor's MissingWidth entry, and
+// finally to 0 if neither is present.
+func (f Font) SpaceWidth() float64 {
+	if adv, ok := f.GlyphAdvance(' '); ok {
+		return adv / 1000
+	}
+	return f.missingWidth() / 1000
+}
+
+// GlyphAdvance returns the advance, in glyph-space units (1000 units to the
+// em), of the glyph for rune r according to the font's Widths array. The
+// second return value reports whether r falls within the font's
+// FirstChar..LastChar range and therefore has an explicit entry; callers
+// needing a usable fallback for characters outside that range should use
+// SpaceWidth, or consult the FontDescriptor's MissingWidth themselves.
+func (f Font) GlyphAdvance(r rune) (float64, bool) {
+	first := f.V.Key("FirstChar").Int64()
+	last := f.V.Key("LastChar").Int64()
+	if int64(r) < first || int64(r) > last {
+		return 0, false
+	}
+	widths := f.V.Key("Widths")
+	idx := int64(r) - first
+	if idx < 0 || idx >= int64(widths.Len()) {
+		return 0, false
+	}
+	return widths.Index(int(idx)).Float64(), true
+}
+
+// missingWidth returns the font's FontDescriptor/MissingWidth entry, or 0 if
+// the font has no FontDescriptor or the entry is absent.
+func (f Font) missingWidth() float64 {
+	desc := f.V.Key("FontDescriptor")
+	if desc.Kind() != Dict {
+		return 0
+	}
+	return desc.Key("MissingWidth").Float64()
+}
@@ -0,0 +1,23 @@
+package pdf
+
+import "testing"
+
+func TestFont_SpaceWidth(t *testing.T) {
+	font := newTestFont(500) // space glyph width 500 units
+	if got, want := font.SpaceWidth(), 500.0/1000; got != want {
+		t.Fatalf("SpaceWidth() = %v, want %v", got, want)
+	}
+}
+
+func TestFont_GlyphAdvance(t *testing.T) {
+	font := newTestFont(500)
+
+	adv, ok := font.GlyphAdvance(' ')
+	if !ok || adv != 500 {
+		t.Fatalf("GlyphAdvance(' ') = (%v, %v), want (500, true)", adv, ok)
+	}
+
+	if _, ok := font.GlyphAdvance('A'); ok {
+		t.Fatalf("GlyphAdvance('A') should report ok=false outside FirstChar..LastChar")
+	}
+}
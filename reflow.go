@@ -0,0 +1,367 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// WrapOptions controls how GetWrappedText reflows a page's merged spans into
+// fixed-width lines.
+type WrapOptions struct {
+	// Width is the target line width, measured in the same text-space units
+	// as Span.X/Span.W (so it lines up with the font metrics used to break
+	// lines), or, for callers with no font metrics to hand, in runes.
+	Width int
+
+	// BreakOnWhitespace restricts break points to existing whitespace
+	// between words. When false, a word wider than Width is hard-broken (or
+	// hyphenated via HyphenationHint) so it still fits on a line.
+	BreakOnWhitespace bool
+
+	// PreserveHardBreaks keeps the paragraph breaks detected from vertical
+	// gaps between Blocks as blank lines in the output, instead of reflowing
+	// across them as if they were a single run of text.
+	PreserveHardBreaks bool
+
+	// HyphenationHint, if set, is consulted for a word that would otherwise
+	// overflow Width. It returns the rune index at which the word may be
+	// split (a hyphen is inserted at that index) and ok == false if the word
+	// should not be split there.
+	HyphenationHint func(word string) (index int, ok bool)
+}
+
+// GetWrappedText reflows the page's merged spans into lines no wider than
+// opts.Width, preserving paragraph breaks detected from vertical gaps
+// between Blocks.
+func (p Page) GetWrappedText(opts WrapOptions) (io.Reader, error) {
+	blocks, err := p.GetTextBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+	if opts.PreserveHardBreaks {
+		for i, block := range blocks {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			for _, line := range wrapBoxes(p.blockBoxes(block), opts) {
+				out.WriteString(line)
+				out.WriteString("\n")
+			}
+		}
+	} else {
+		// Without PreserveHardBreaks, a Block boundary is not a line break:
+		// collect every block's boxes before wrapping so text reflows across
+		// them as a single run.
+		var boxes []wrapBox
+		for _, block := range blocks {
+			boxes = append(boxes, p.blockBoxes(block)...)
+		}
+		for _, line := range wrapBoxes(boxes, opts) {
+			out.WriteString(line)
+			out.WriteString("\n")
+		}
+	}
+	return strings.NewReader(out.String()), nil
+}
+
+// GetWrappedText reflows every page's merged spans into lines no wider than
+// opts.Width, in page order.
+func (r *Reader) GetWrappedText(opts WrapOptions) (io.Reader, error) {
+	var out bytes.Buffer
+	for i := 1; i <= r.NumPage(); i++ {
+		pr, err := r.Page(i).GetWrappedText(opts)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(&out, pr); err != nil {
+			return nil, err
+		}
+	}
+	return &out, nil
+}
+
+// wrapBox is a single indivisible run of text (a "box", in Knuth's line-
+// breaking terminology) together with the glue-stretch budget of the space
+// that precedes it.
+type wrapBox struct {
+	text  string
+	width float64 // text-space units, or rune count when fontResolved is false
+
+	font         Font
+	fontSize     float64
+	fontResolved bool
+
+	// glueStretch is how far the inter-word space before this box can
+	// plausibly vary before it reads as more than ordinary word spacing. It
+	// is derived from the box's font's space-glyph advance and is used only
+	// to judge raggedness during the backtrack pass, not to size the literal
+	// space written to the output.
+	glueStretch float64
+}
+
+// defaultGlueStretch is used for boxes whose font could not be resolved, in
+// the same degraded (rune-count) unit system as their width.
+const defaultGlueStretch = 1.0
+
+// blockBoxes flattens a Block's spans, across all of its Lines, into the
+// sequence of boxes wrapBoxes reflows. A span with no internal whitespace
+// becomes a single box whose width is its advanceSpaceAware advance, the
+// same unit the merging pipeline itself uses; a span holding several words
+// is split into one box per word, each measured from its font's per-glyph
+// advances so wrapping stays correct for multi-byte runes.
+func (p Page) blockBoxes(block Block) []wrapBox {
+	var boxes []wrapBox
+	for _, line := range block.Lines {
+		for _, span := range line.Spans {
+			font, resolved := p.resolveFont(span.Font)
+			stretch := defaultGlueStretch
+			if resolved {
+				stretch = font.SpaceWidth() * span.FontSize
+			}
+
+			words := strings.Fields(span.S)
+			if len(words) == 1 {
+				width := float64(utf8.RuneCountInString(words[0]))
+				if resolved {
+					width = span.advanceSpaceAware(font)
+				}
+				boxes = append(boxes, wrapBox{
+					text: words[0], width: width,
+					font: font, fontSize: span.FontSize, fontResolved: resolved,
+					glueStretch: stretch,
+				})
+				continue
+			}
+			for _, w := range words {
+				width := float64(utf8.RuneCountInString(w))
+				if resolved {
+					width = runeSpanWidth([]rune(w), font, span.FontSize)
+				}
+				boxes = append(boxes, wrapBox{
+					text: w, width: width,
+					font: font, fontSize: span.FontSize, fontResolved: resolved,
+					glueStretch: stretch,
+				})
+			}
+		}
+	}
+	return boxes
+}
+
+// resolveFont looks up name among the page's fonts. A font that cannot be
+// resolved degrades the affected boxes to rune-count measurement rather than
+// aborting the wrap, so one span with an unresolvable font doesn't prevent
+// the rest of the page from reflowing.
+func (p Page) resolveFont(name string) (Font, bool) {
+	font, err := p.Font(name)
+	if err != nil {
+		return Font{}, false
+	}
+	return font, true
+}
+
+// runeSpanWidth sums the per-glyph advances of runes under font, falling
+// back to the font's space-glyph advance for any rune outside its Widths
+// range.
+func runeSpanWidth(runes []rune, font Font, fontSize float64) float64 {
+	total := 0.0
+	for _, r := range runes {
+		if adv, ok := font.GlyphAdvance(r); ok {
+			total += adv / 1000 * fontSize
+			continue
+		}
+		total += font.SpaceWidth() * fontSize
+	}
+	return total
+}
+
+// wrapBoxes packs boxes onto lines no wider than opts.Width: boxes are
+// indivisible, the space between them is glue, and a greedy first pass is
+// followed by a single backtrack pass that pulls a box back onto the
+// following line when doing so relieves a line that came out looser than
+// its trailing glue could plausibly stretch to explain.
+func wrapBoxes(boxes []wrapBox, opts WrapOptions) []string {
+	if len(boxes) == 0 {
+		return nil
+	}
+	limit := float64(opts.Width)
+	if limit <= 0 {
+		return []string{joinBoxes(boxes)}
+	}
+
+	boxes = explodeOverflowingBoxes(boxes, limit, opts)
+	breaks := deRagBreaks(boxes, greedyBreaks(boxes, limit), limit)
+
+	lines := make([]string, len(breaks))
+	start := 0
+	for i, end := range breaks {
+		lines[i] = joinBoxes(boxes[start : end+1])
+		start = end + 1
+	}
+	return lines
+}
+
+// explodeOverflowingBoxes replaces any box wider than limit on its own with
+// a sequence of hard-broken (and, where HyphenationHint allows, hyphenated)
+// continuation boxes, so the packing passes below can keep treating every
+// box as indivisible.
+func explodeOverflowingBoxes(boxes []wrapBox, limit float64, opts WrapOptions) []wrapBox {
+	var out []wrapBox
+	for _, b := range boxes {
+		for b.width > limit {
+			head, rest := splitOverflowingBox(b, limit, opts)
+			if rest == "" {
+				break
+			}
+			piece := b
+			piece.text = head
+			piece.width = boxTextWidth(head, b)
+			out = append(out, piece)
+
+			b.text = rest
+			b.width = boxTextWidth(rest, b)
+			b.glueStretch = 0 // a hyphenated continuation isn't a real word break
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func boxTextWidth(text string, like wrapBox) float64 {
+	if !like.fontResolved {
+		return float64(utf8.RuneCountInString(text))
+	}
+	return runeSpanWidth([]rune(text), like.font, like.fontSize)
+}
+
+// splitOverflowingBox splits a box too wide for limit into a head (with a
+// trailing hyphen only when HyphenationHint accepts the split and the
+// hyphen itself still fits within limit) and the remaining text. It returns
+// rest == "" when the box can't usefully be split further (no font metrics,
+// or empty text), leaving the caller to emit it unbroken.
+func splitOverflowingBox(box wrapBox, limit float64, opts WrapOptions) (head, rest string) {
+	runes := []rune(box.text)
+	if opts.BreakOnWhitespace || !box.fontResolved || len(runes) < 2 {
+		return box.text, ""
+	}
+
+	if hint := opts.HyphenationHint; hint != nil {
+		if idx, ok := hint(box.text); ok && idx > 0 && idx < len(runes) {
+			hyphenWidth := 0.0
+			if adv, ok := box.font.GlyphAdvance('-'); ok {
+				hyphenWidth = adv / 1000 * box.fontSize
+			}
+			if runeSpanWidth(runes[:idx], box.font, box.fontSize)+hyphenWidth <= limit {
+				return string(runes[:idx]) + "-", string(runes[idx:])
+			}
+		}
+	}
+
+	cut := measureRuneCut(runes, box.font, box.fontSize, limit)
+	if cut <= 0 {
+		cut = 1 // always make progress
+	}
+	if cut >= len(runes) {
+		return box.text, ""
+	}
+	return string(runes[:cut]), string(runes[cut:])
+}
+
+// measureRuneCut returns how many leading runes of runes fit within budget,
+// measured by the font's per-glyph advances.
+func measureRuneCut(runes []rune, font Font, fontSize, budget float64) int {
+	used := 0.0
+	for i, r := range runes {
+		w := font.SpaceWidth() * fontSize
+		if adv, ok := font.GlyphAdvance(r); ok {
+			w = adv / 1000 * fontSize
+		}
+		if used+w > budget {
+			return i
+		}
+		used += w
+	}
+	return len(runes)
+}
+
+// greedyBreaks returns, for each line, the index of its last box: boxes are
+// packed onto the current line until the next one (plus its separating
+// space) would overflow limit.
+func greedyBreaks(boxes []wrapBox, limit float64) []int {
+	var breaks []int
+	lineStart := 0
+	used := 0.0
+	for i, b := range boxes {
+		w := b.width
+		if i > lineStart {
+			// The separating space is glue, sized like the box's own width:
+			// a font-resolved space advance for font-resolved boxes, or a
+			// single rune for boxes measured in rune counts.
+			w += b.glueStretch
+		}
+		if i > lineStart && used+w > limit {
+			breaks = append(breaks, i-1)
+			lineStart = i
+			used = b.width
+			continue
+		}
+		used += w
+	}
+	return append(breaks, len(boxes)-1)
+}
+
+// deRagBreaks makes one backward pass over the greedy breakpoints, pulling
+// a line's last box onto the following line whenever the line was looser
+// than its last box's glueStretch could plausibly explain and doing so
+// still fits the next line - trading one ragged line for two more even
+// ones.
+func deRagBreaks(boxes []wrapBox, breaks []int, limit float64) []int {
+	start := 0
+	for li := 0; li < len(breaks)-1; li++ {
+		end := breaks[li]
+		if end > start {
+			loose := limit - lineWidth(boxes[start:end+1])
+			last := boxes[end]
+			nextEnd := breaks[li+1]
+			// Pulling last onto the next line adds it back as that line's
+			// first box, preceded by its own glue width rather than a
+			// hardcoded single unit.
+			if loose > last.glueStretch && lineWidth(boxes[end+1:nextEnd+1])+last.glueStretch+last.width <= limit {
+				breaks[li] = end - 1
+			}
+		}
+		start = breaks[li] + 1
+	}
+	return breaks
+}
+
+// lineWidth sums a run of boxes as they would be laid out on one line: each
+// box's own width plus, between boxes, the glue width of the box that
+// follows the gap - the same unit system blockBoxes measured the boxes in,
+// so font-resolved lines are judged in text-space units and rune-mode lines
+// in rune counts.
+func lineWidth(boxes []wrapBox) float64 {
+	total := 0.0
+	for i, b := range boxes {
+		if i > 0 {
+			total += b.glueStretch
+		}
+		total += b.width
+	}
+	return total
+}
+
+func joinBoxes(boxes []wrapBox) string {
+	var sb strings.Builder
+	for i, b := range boxes {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(b.text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func plainBox(text string) wrapBox {
+	return wrapBox{text: text, width: float64(utf8.RuneCountInString(text)), glueStretch: defaultGlueStretch}
+}
+
+func fontBox(text string, font Font) wrapBox {
+	return wrapBox{
+		text: text, width: runeSpanWidth([]rune(text), font, 1),
+		font: font, fontSize: 1, fontResolved: true,
+		glueStretch: font.SpaceWidth(),
+	}
+}
+
+func TestWrapBoxes_Basic(t *testing.T) {
+	boxes := []wrapBox{
+		plainBox("the"), plainBox("quick"), plainBox("brown"), plainBox("fox"), plainBox("jumps"),
+	}
+	got := wrapBoxes(boxes, WrapOptions{Width: 10})
+	want := []string{"the quick", "brown fox", "jumps"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrapBoxes() = %v, want %v", got, want)
+	}
+}
+
+func TestWrapBoxes_HardBreakLongWordNoHyphen(t *testing.T) {
+	font := newTestFont(1000) // every rune costs one text-space unit at FontSize 1
+	boxes := []wrapBox{fontBox("supercalifragilistic", font)}
+	got := wrapBoxes(boxes, WrapOptions{Width: 8})
+	want := []string{"supercal", "ifragili", "stic"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrapBoxes() = %v, want %v (hard breaks must not insert a hyphen without a hint)", got, want)
+	}
+}
+
+func TestWrapBoxes_HyphenationHintStaysWithinWidth(t *testing.T) {
+	font := newTestFont(1000)
+	boxes := []wrapBox{fontBox("wonderful", font)}
+	hint := func(word string) (int, bool) {
+		if word == "wonderful" {
+			return 5, true // wonde-rful
+		}
+		return 0, false
+	}
+	got := wrapBoxes(boxes, WrapOptions{Width: 6, HyphenationHint: hint})
+	want := []string{"wonde-", "rful"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrapBoxes() = %v, want %v", got, want)
+	}
+}
+
+func TestWrapBoxes_BreakOnWhitespaceKeepsLongWordIntact(t *testing.T) {
+	boxes := []wrapBox{plainBox("short"), plainBox("areallylongwordthatoverflows")}
+	got := wrapBoxes(boxes, WrapOptions{Width: 10, BreakOnWhitespace: true})
+	want := []string{"short", "areallylongwordthatoverflows"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrapBoxes() = %v, want %v", got, want)
+	}
+}
+
+func TestRuneSpanWidth_MultibyteSafe(t *testing.T) {
+	font := newTestFont(1000)
+	// "héllo" mixes a multibyte rune; width must be measured in runes, not
+	// bytes, and must not panic or corrupt UTF-8 when later sliced.
+	word := "héllo"
+	if n := len([]rune(word)); n != 5 {
+		t.Fatalf("test fixture has %d runes, want 5", n)
+	}
+	if w := runeSpanWidth([]rune(word), font, 1); w != 5 {
+		t.Fatalf("runeSpanWidth() = %v, want 5", w)
+	}
+}
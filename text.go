@@ -0,0 +1,170 @@
+package pdf
+
+import "strings"
+
+// MergeOptions controls how adjacent text spans produced during extraction
+// are combined into a single Span.
+type MergeOptions struct {
+	// BaselineTolerance is the maximum allowed difference between the Y
+	// coordinates of two spans for them to be considered on the same line.
+	BaselineTolerance float64
+
+	// SpaceMultiplier scales the font's space-glyph advance to decide how
+	// much slack to allow between two spans before they are treated as
+	// separate words rather than merged into one.
+	SpaceMultiplier float64
+
+	// LetterSpacingAware, when true, also subtracts span2's leading half-Tc
+	// contribution when computing the gap between spans, so runs split
+	// purely by a Tc change still merge. span1's trailing edge is always
+	// measured with advanceSpaceAware (trailing whitespace and span1's own
+	// trailing half-Tc trimmed) regardless of this flag, since that is
+	// simply the span's true visual edge; this toggle only controls whether
+	// span2's side of the split is given the same treatment.
+	LetterSpacingAware bool
+}
+
+// DefaultMergeOptions are the options used when callers do not supply their
+// own MergeOptions.
+var DefaultMergeOptions = MergeOptions{
+	BaselineTolerance: 0.5,
+	SpaceMultiplier:   1.5,
+}
+
+// Span is a contiguous run of text sharing a single font, size, and
+// baseline.
+type Span struct {
+	S        string
+	Font     string
+	FontSize float64
+	X, Y     float64
+	W        float64
+
+	// LeadingSpacing and TrailingSpacing hold half of the Tc (character
+	// spacing) value in effect when the span's first and last glyph,
+	// respectively, were drawn. They are zero for spans built without an
+	// active Tc.
+	LeadingSpacing  float64
+	TrailingSpacing float64
+}
+
+// advanceSpaceAware returns the span's visual advance with trailing
+// whitespace and the span's trailing letter spacing removed. Merging and
+// line-building both use this instead of W so a trailing space (or the
+// trailing half of a Tc contribution) doesn't push an otherwise-short line
+// past a right-margin heuristic.
+func (s Span) advanceSpaceAware(font Font) float64 {
+	w := s.W - s.TrailingSpacing
+	trimmed := strings.TrimRight(s.S, " \t")
+	if n := len(s.S) - len(trimmed); n > 0 {
+		w -= font.SpaceWidth() * s.FontSize * float64(n)
+	}
+	if w < 0 {
+		return 0
+	}
+	return w
+}
+
+// canBeMerged reports whether span2 immediately follows span1 closely enough
+// to be treated as a continuation of the same run, given the character and
+// word spacing (Tc/Tw) in effect and the supplied options.
+func canBeMerged(span1, span2 Span, font Font, charSpacing, wordSpacing float64, opts MergeOptions) bool {
+	if span1.Font != span2.Font || span1.FontSize != span2.FontSize {
+		return false
+	}
+	if absFloat(span1.Y-span2.Y) > opts.BaselineTolerance {
+		return false
+	}
+
+	expected := font.SpaceWidth()*span1.FontSize + charSpacing + wordSpacing
+	// advanceSpaceAware already excludes span1's trailing whitespace and
+	// trailing letter spacing, so the gap is measured from its true visual
+	// edge rather than the raw (spacing-inflated) W.
+	gap := span2.X - (span1.X + span1.advanceSpaceAware(font))
+	if opts.LetterSpacingAware {
+		gap -= span2.LeadingSpacing
+	}
+	if gap < 0 {
+		return false
+	}
+	return gap <= expected*opts.SpaceMultiplier
+}
+
+// CanBeMerged is the exported form of canBeMerged, for callers building
+// their own extraction pipelines on top of Span and Font.
+func CanBeMerged(span1, span2 Span, font Font, charSpacing, wordSpacing float64, opts MergeOptions) bool {
+	return canBeMerged(span1, span2, font, charSpacing, wordSpacing, opts)
+}
+
+// newSpanFromCharSpacing builds a Span carrying the LeadingSpacing and
+// TrailingSpacing contributed by charSpacing (the Tc value in effect while
+// it was drawn), split half to each side of the span per the go-text/
+// typesetting model. This is what the content-stream interpreter calls for
+// every span it emits; charSpacing of 0 yields a Span indistinguishable
+// from one built without this helper.
+func newSpanFromCharSpacing(s, font string, fontSize, x, y, w, charSpacing float64) Span {
+	half := charSpacing / 2
+	return Span{
+		S: s, Font: font, FontSize: fontSize, X: x, Y: y, W: w,
+		LeadingSpacing:  half,
+		TrailingSpacing: half,
+	}
+}
+
+// VisualWidth returns the line's width for right-margin heuristics such as
+// GetTextByRow's short-line paragraph-end detection: every span but the
+// last contributes its full W, and the last contributes its
+// advanceSpaceAware width, so a trailing space (or trailing letter
+// spacing) doesn't make an otherwise-short line look like it reached the
+// margin.
+func (l Line) VisualWidth(font Font) float64 {
+	if len(l.Spans) == 0 {
+		return 0
+	}
+	w := 0.0
+	for _, span := range l.Spans[:len(l.Spans)-1] {
+		w += span.W
+	}
+	last := l.Spans[len(l.Spans)-1]
+	return w + last.advanceSpaceAware(font)
+}
+
+// textRun is the minimal input the content-stream interpreter hands to the
+// merging pipeline for each text-showing operator: the decoded text, its
+// position and rendered width, and the Tc/Tw in effect.
+type textRun struct {
+	S                        string
+	Font                     string
+	FontSize, X, Y, W        float64
+	CharSpacing, WordSpacing float64
+}
+
+// mergeRuns turns a sequence of textRuns into a Line, merging adjacent runs
+// with canBeMerged. Each run becomes a Span via newSpanFromCharSpacing
+// first, so the merge decision sees the same letter-spacing split a real
+// content stream would have produced.
+func mergeRuns(runs []textRun, font Font, opts MergeOptions) Line {
+	var line Line
+	for _, run := range runs {
+		span := newSpanFromCharSpacing(run.S, run.Font, run.FontSize, run.X, run.Y, run.W, run.CharSpacing)
+		if n := len(line.Spans); n > 0 {
+			prev := line.Spans[n-1]
+			if canBeMerged(prev, span, font, run.CharSpacing, run.WordSpacing, opts) {
+				prev.S += span.S
+				prev.W = (span.X + span.W) - prev.X
+				prev.TrailingSpacing = span.TrailingSpacing
+				line.Spans[n-1] = prev
+				continue
+			}
+		}
+		line.Spans = append(line.Spans, span)
+	}
+	return line
+}
+
+func absFloat(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -47,3 +47,94 @@ func TestCanBeMerged_WithSpacingOperators(t *testing.T) {
 		t.Fatalf("expected spans not to merge without Tc/Tw")
 	}
 }
+
+func TestCanBeMerged_LetterSpacingAware(t *testing.T) {
+	font := newTestFont(250) // space glyph width 250 units
+	opts := DefaultMergeOptions
+	opts.LetterSpacingAware = true
+
+	span1 := Span{Font: "F1", FontSize: 12, X: 0, Y: 0, W: 15, TrailingSpacing: 1.0}
+	span2 := Span{Font: "F1", FontSize: 12, W: 5, LeadingSpacing: 2.0}
+	expected := 250.0 / 1000 * span1.FontSize
+	// advanceSpaceAware already strips span1.TrailingSpacing from the gap;
+	// only span2.LeadingSpacing needs subtracting on top of that.
+	span2.X = span1.X + span1.advanceSpaceAware(font) + expected + span2.LeadingSpacing
+
+	if !canBeMerged(span1, span2, font, 0, 0, opts) {
+		t.Fatalf("expected spans split only by Tc to merge with LetterSpacingAware")
+	}
+
+	// Without the toggle, span2's leading spacing still counts toward the
+	// gap, so the same spans look too far apart to merge.
+	if canBeMerged(span1, span2, font, 0, 0, DefaultMergeOptions) {
+		t.Fatalf("expected spans not to merge without LetterSpacingAware")
+	}
+}
+
+func TestCanBeMerged_LetterSpacingAwareFromCharSpacing(t *testing.T) {
+	font := newTestFont(250) // space glyph width 250 units
+	opts := DefaultMergeOptions
+	opts.LetterSpacingAware = true
+	charSpacing := 6.0 // Tc in effect for both runs
+
+	span1 := newSpanFromCharSpacing("foo", "F1", 12, 0, 0, 15, charSpacing)
+	expected := 250.0 / 1000 * span1.FontSize
+	x2 := span1.X + span1.advanceSpaceAware(font) + expected + charSpacing/2
+	span2 := newSpanFromCharSpacing("bar", "F1", 12, x2, 0, 5, charSpacing)
+
+	if !canBeMerged(span1, span2, font, 0, 0, opts) {
+		t.Fatalf("expected spans split only by a Tc change to merge with LetterSpacingAware")
+	}
+	if canBeMerged(span1, span2, font, 0, 0, DefaultMergeOptions) {
+		t.Fatalf("expected spans not to merge without LetterSpacingAware")
+	}
+}
+
+func TestMergeRuns_UsesCharSpacingAndVisualWidth(t *testing.T) {
+	font := newTestFont(250) // space glyph width 250 units
+	opts := DefaultMergeOptions
+	opts.LetterSpacingAware = true
+	charSpacing := 6.0 // Tc in effect for both runs
+
+	span1 := newSpanFromCharSpacing("foo", "F1", 12, 0, 0, 15, charSpacing)
+	expected := 250.0 / 1000 * span1.FontSize
+	x2 := span1.X + span1.advanceSpaceAware(font) + expected + charSpacing/2
+
+	runs := []textRun{
+		{S: "foo", Font: "F1", FontSize: 12, X: 0, Y: 0, W: 15, CharSpacing: charSpacing},
+		{S: "bar", Font: "F1", FontSize: 12, X: x2, Y: 0, W: 5, CharSpacing: charSpacing},
+	}
+
+	line := mergeRuns(runs, font, opts)
+	if len(line.Spans) != 1 || line.Spans[0].S != "foobar" {
+		t.Fatalf("mergeRuns() = %+v, want a single merged %q span", line.Spans, "foobar")
+	}
+	if w := line.VisualWidth(font); w <= 0 {
+		t.Fatalf("VisualWidth() = %v, want > 0", w)
+	}
+}
+
+func TestLine_VisualWidth(t *testing.T) {
+	font := newTestFont(500) // space glyph width 500 units
+	spaceAdvance := 500.0 / 1000 * 12.0
+
+	line := Line{Spans: []Span{
+		{S: "hello", Font: "F1", FontSize: 12, W: 40},
+		{S: "hi  ", Font: "F1", FontSize: 12, W: 20 + 2*spaceAdvance, TrailingSpacing: 1.0},
+	}}
+	want := 40 + (20 - 1.0)
+	if got := line.VisualWidth(font); got != want {
+		t.Fatalf("VisualWidth() = %v, want %v", got, want)
+	}
+}
+
+func TestSpan_AdvanceSpaceAware(t *testing.T) {
+	font := newTestFont(500) // space glyph width 500 units
+	spaceAdvance := 500.0 / 1000 * 12.0
+
+	span := Span{S: "hi  ", Font: "F1", FontSize: 12, W: 20 + 2*spaceAdvance, TrailingSpacing: 1.0}
+	want := 20 - 1.0
+	if got := span.advanceSpaceAware(font); got != want {
+		t.Fatalf("advanceSpaceAware() = %v, want %v", got, want)
+	}
+}